@@ -0,0 +1,21 @@
+package main
+
+import "fmt"
+
+// defaultStdinCapBytes bounds the stdin capture buffer until the user grows
+// it with '+' or overrides it with -buf.
+const defaultStdinCapBytes = 40 * 1024 * 1024
+
+// formatBytes renders n as a short human-readable size, e.g. 40M.
+func formatBytes(n int) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := int64(unit), 0
+	for v := int64(n) / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.0f%c", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Completer completes the token at pos within line, returning the text
+// before the token (head), the candidate completions, and whatever follows
+// the token unchanged (tail).
+type Completer interface {
+	Complete(line string, pos int) (head string, candidates []string, tail string)
+}
+
+// pathCacheTTL bounds how long the $PATH executable cache is trusted before
+// it's refreshed on the next completion attempt.
+const pathCacheTTL = 30 * time.Second
+
+// flagTable offers a small built-in set of flags for commands this tool is
+// commonly piped through; it's deliberately not exhaustive.
+var flagTable = map[string][]string{
+	"grep": {"-i", "-v", "-n", "-c", "-r", "-E", "-o", "-A", "-B", "-C", "--color"},
+	"awk":  {"-F", "-v", "-f"},
+	"sort": {"-n", "-r", "-u", "-k", "-t", "-f"},
+	"jq":   {"-r", "-c", "-s", "-e", "-n"},
+}
+
+// defaultCompleter completes executable names from $PATH, filesystem paths,
+// and flags for a handful of well-known commands.
+type defaultCompleter struct {
+	mu       sync.Mutex
+	execs    []string
+	cachedAt time.Time
+}
+
+// newDefaultCompleter builds the executable cache and arranges for it to be
+// refreshed on SIGHUP.
+func newDefaultCompleter() *defaultCompleter {
+	c := &defaultCompleter{}
+	c.refreshExecs()
+	c.watchSignal()
+	return c
+}
+
+func (c *defaultCompleter) watchSignal() {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGHUP)
+	go func() {
+		for range sigCh {
+			c.refreshExecs()
+		}
+	}()
+}
+
+func (c *defaultCompleter) refreshExecs() {
+	seen := make(map[string]bool)
+	var execs []string
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			if e.IsDir() || seen[e.Name()] {
+				continue
+			}
+			info, err := e.Info()
+			if err != nil || info.Mode()&0o111 == 0 {
+				continue
+			}
+			seen[e.Name()] = true
+			execs = append(execs, e.Name())
+		}
+	}
+	sort.Strings(execs)
+
+	c.mu.Lock()
+	c.execs = execs
+	c.cachedAt = time.Now()
+	c.mu.Unlock()
+}
+
+func (c *defaultCompleter) executables() []string {
+	c.mu.Lock()
+	stale := time.Since(c.cachedAt) > pathCacheTTL
+	c.mu.Unlock()
+	if stale {
+		c.refreshExecs()
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.execs
+}
+
+// Complete implements Completer: it decides, from the current pipe segment,
+// whether the token being completed is a command name, a flag, or a path.
+func (c *defaultCompleter) Complete(line string, pos int) (string, []string, string) {
+	head, token, tail := tokenAt(line, pos)
+	segment := currentPipeSegment(head)
+
+	var candidates []string
+	switch {
+	case strings.HasPrefix(token, "-") && !isFirstToken(segment):
+		candidates = matchPrefix(flagsFor(segment), token)
+	case isFirstToken(segment):
+		candidates = matchPrefix(c.executables(), token)
+	default:
+		candidates = completePath(token)
+	}
+	return head, candidates, tail
+}
+
+// tokenAt splits line around pos into the text before the token the cursor
+// sits at the end of (head), the token itself, and whatever follows pos
+// (tail), treating quoted runs as non-breaking so a space inside a quoted
+// string doesn't start a new token.
+func tokenAt(line string, pos int) (head, token, tail string) {
+	if pos < 0 {
+		pos = 0
+	}
+	if pos > len(line) {
+		pos = len(line)
+	}
+	before := line[:pos]
+	tail = line[pos:]
+
+	// quoteAtIndex[i] is the quote character open just before byte i of
+	// before, or emptyRune if unquoted there.
+	quoteAtIndex := make([]rune, len(before)+1)
+	q := emptyRune
+	for i, r := range before {
+		quoteAtIndex[i] = q
+		switch r {
+		case '\'', '"', '`':
+			if q == emptyRune {
+				q = r
+			} else if q == r {
+				q = emptyRune
+			}
+		}
+	}
+	quoteAtIndex[len(before)] = q
+
+	start := len(before)
+	for start > 0 {
+		prev := before[start-1]
+		if quoteAtIndex[start-1] == emptyRune && (prev == ' ' || prev == '|') {
+			break
+		}
+		start--
+	}
+	return before[:start], before[start:], tail
+}
+
+// currentPipeSegment returns the trimmed text of head within the pipe
+// segment the cursor is in, i.e. everything after the last unquoted '|'.
+func currentPipeSegment(head string) string {
+	q := emptyRune
+	lastPipe := -1
+	for i, r := range head {
+		switch r {
+		case '\'', '"', '`':
+			if q == emptyRune {
+				q = r
+			} else if q == r {
+				q = emptyRune
+			}
+		case '|':
+			if q == emptyRune {
+				lastPipe = i
+			}
+		}
+	}
+	return strings.TrimSpace(head[lastPipe+1:])
+}
+
+// isFirstToken reports whether segment has no tokens yet, meaning the token
+// being completed is the command name of its pipe stage.
+func isFirstToken(segment string) bool {
+	return strings.TrimSpace(segment) == ""
+}
+
+func flagsFor(segment string) []string {
+	fields := strings.Fields(segment)
+	if len(fields) == 0 {
+		return nil
+	}
+	return flagTable[fields[0]]
+}
+
+func matchPrefix(options []string, prefix string) []string {
+	var out []string
+	for _, o := range options {
+		if strings.HasPrefix(o, prefix) {
+			out = append(out, o)
+		}
+	}
+	return out
+}
+
+// completePath completes filesystem paths relative to the current working
+// directory, suffixing directories with '/'.
+func completePath(token string) []string {
+	dir, prefix := filepath.Split(token)
+	searchDir := dir
+	if searchDir == "" {
+		searchDir = "."
+	}
+	entries, err := os.ReadDir(searchDir)
+	if err != nil {
+		return nil
+	}
+	var out []string
+	for _, e := range entries {
+		if !strings.HasPrefix(e.Name(), prefix) {
+			continue
+		}
+		name := dir + e.Name()
+		if e.IsDir() {
+			name += "/"
+		}
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// cursorAtTokenEnd reports whether pos sits at the end of a token, i.e. at
+// the end of the line or just before a space or pipe.
+func cursorAtTokenEnd(value string, pos int) bool {
+	if pos >= len(value) {
+		return true
+	}
+	next := value[pos]
+	return next == ' ' || next == '|'
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/google/shlex"
+)
+
+// aggregatingCommands maps a command name to a predicate over its arguments
+// reporting whether that invocation needs to see all of stdin before it can
+// produce meaningful output (e.g. `sort`, `wc -l`), as opposed to one that
+// can usefully process input incrementally.
+var aggregatingCommands = map[string]func(args []string) bool{
+	"wc":   func(args []string) bool { return true },
+	"sort": func(args []string) bool { return true },
+	"tac":  func(args []string) bool { return true },
+	"uniq": func(args []string) bool { return hasFlag(args, "-c") },
+	"jq":   func(args []string) bool { return hasFlag(args, "-s") },
+	"awk":  func(args []string) bool { return !hasFlagValue(args, "-W", "interactive") },
+	"head": func(args []string) bool { return hasNegativeCount(args) },
+	"tail": func(args []string) bool { return !hasFlag(args, "-f") },
+}
+
+// isAggregating reports whether any stage of the pipeline needs the full
+// input before it can produce meaningful output, e.g. `sort | grep foo`
+// still needs to wait for EOF even though `sort` isn't the last stage.
+func isAggregating(commands []string) bool {
+	for _, stage := range commands {
+		parts, err := shlex.Split(strings.TrimSpace(stage))
+		if err != nil || len(parts) == 0 {
+			continue
+		}
+		if predicate, ok := aggregatingCommands[parts[0]]; ok && predicate(parts[1:]) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasFlag(args []string, flag string) bool {
+	for _, a := range args {
+		if a == flag {
+			return true
+		}
+	}
+	return false
+}
+
+func hasFlagValue(args []string, flag, value string) bool {
+	for i, a := range args {
+		if a == flag && i+1 < len(args) && args[i+1] == value {
+			return true
+		}
+	}
+	return false
+}
+
+// hasNegativeCount reports whether args request `head`'s "all but the last
+// N lines" form, e.g. `-n -5` or `-n-5`.
+func hasNegativeCount(args []string) bool {
+	for i, a := range args {
+		if a == "-n" && i+1 < len(args) && strings.HasPrefix(args[i+1], "-") {
+			return true
+		}
+		if strings.HasPrefix(a, "-n-") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package main
+
+import "testing"
+
+func TestIsAggregating(t *testing.T) {
+	cases := []struct {
+		name     string
+		commands []string
+		want     bool
+	}{
+		{"last stage aggregating", []string{"grep foo", "wc -l"}, true},
+		{"non-aggregating last stage", []string{"grep foo", "sed s/a/b/"}, false},
+		{"aggregating stage not last", []string{"sort", "grep foo"}, true},
+		{"wc -l anywhere", []string{"wc -l", "cat"}, true},
+		{"uniq without -c is not aggregating", []string{"uniq"}, false},
+		{"uniq -c is aggregating", []string{"uniq -c"}, true},
+		{"jq without -s is not aggregating", []string{"jq ."}, false},
+		{"jq -s is aggregating", []string{"jq -s ."}, true},
+		{"awk is aggregating by default", []string{"awk '{print}'"}, true},
+		{"awk -W interactive is not aggregating", []string{"awk -W interactive '{print}'"}, false},
+		{"head with positive count is not aggregating", []string{"head -n 5"}, false},
+		{"head with negative count is aggregating", []string{"head -n -5"}, true},
+		{"head with -n-5 form is aggregating", []string{"head -n-5"}, true},
+		{"tail -f is not aggregating", []string{"tail -f"}, false},
+		{"tail without -f is aggregating", []string{"tail -n 5"}, true},
+		{"tac is aggregating", []string{"tac"}, true},
+		{"no commands", nil, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := isAggregating(c.commands); got != c.want {
+				t.Errorf("isAggregating(%v) = %v, want %v", c.commands, got, c.want)
+			}
+		})
+	}
+}
+
+func TestHasNegativeCount(t *testing.T) {
+	cases := []struct {
+		args []string
+		want bool
+	}{
+		{[]string{"-n", "-5"}, true},
+		{[]string{"-n-5"}, true},
+		{[]string{"-n", "5"}, false},
+		{[]string{"-c", "5"}, false},
+		{nil, false},
+	}
+	for _, c := range cases {
+		if got := hasNegativeCount(c.args); got != c.want {
+			t.Errorf("hasNegativeCount(%v) = %v, want %v", c.args, got, c.want)
+		}
+	}
+}
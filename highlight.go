@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/formatters"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// highlightMode selects how (or whether) the output viewport is syntax
+// highlighted.
+type highlightMode int
+
+const (
+	highlightOff highlightMode = iota
+	highlightAuto
+	highlightExplicit
+)
+
+// highlightLexers is the fixed rotation of lexers offered once H cycles past
+// "auto". Each name must be one chroma actually registers (verified against
+// lexers.Get); "log" isn't a real chroma lexer, so plaintext stands in for
+// it.
+var highlightLexers = []string{"json", "yaml", "plaintext", "go", "python", "bash"}
+
+// highlightTheme picks a chroma style by background color, overridable via
+// $TXTFLOW_THEME.
+func highlightTheme() string {
+	if theme := os.Getenv("TXTFLOW_THEME"); theme != "" {
+		return theme
+	}
+	if lipgloss.HasDarkBackground() {
+		return "native"
+	}
+	return "tango"
+}
+
+// cycleHighlight advances through off -> auto -> each lexer in
+// highlightLexers -> off.
+func (m *model) cycleHighlight() {
+	switch m.highlightMode {
+	case highlightOff:
+		m.highlightMode = highlightAuto
+	case highlightAuto:
+		m.highlightMode = highlightExplicit
+		m.highlightLexerIdx = 0
+	case highlightExplicit:
+		m.highlightLexerIdx++
+		if m.highlightLexerIdx >= len(highlightLexers) {
+			m.highlightMode = highlightOff
+		}
+	}
+	m.refreshOutput()
+}
+
+func (m *model) highlightModeLabel() string {
+	switch m.highlightMode {
+	case highlightOff:
+		return "off"
+	case highlightAuto:
+		return "auto"
+	default:
+		return highlightLexers[m.highlightLexerIdx]
+	}
+}
+
+// guessLexerFromCommand falls back to the terminal command when chroma's
+// content-based analysis can't identify a lexer, e.g. `jq` implies JSON.
+// Returned names must be real chroma lexers (verified against lexers.Get);
+// chroma has no dedicated "log"/"syslog" lexer, so plaintext stands in.
+func guessLexerFromCommand(cmdline string) string {
+	switch {
+	case strings.Contains(cmdline, "jq"):
+		return "json"
+	case strings.Contains(cmdline, ".log"):
+		return "plaintext"
+	}
+	return ""
+}
+
+// highlight renders content through chroma using the currently selected
+// lexer, returning content unmodified when highlighting is off, empty, or
+// tokenizing fails.
+func (m *model) highlight(content string) string {
+	if m.highlightMode == highlightOff || content == "" {
+		return content
+	}
+
+	var lexer chroma.Lexer
+	switch m.highlightMode {
+	case highlightAuto:
+		lexer = lexers.Analyse(content)
+		if lexer == nil {
+			lexer = lexers.Get(guessLexerFromCommand(m.textInput.Value()))
+		}
+	case highlightExplicit:
+		lexer = lexers.Get(highlightLexers[m.highlightLexerIdx])
+	}
+	if lexer == nil {
+		return content
+	}
+	lexer = chroma.Coalesce(lexer)
+
+	iterator, err := lexer.Tokenise(nil, content)
+	if err != nil {
+		return content
+	}
+
+	style := styles.Get(highlightTheme())
+	if style == nil {
+		style = styles.Fallback
+	}
+
+	var buf bytes.Buffer
+	if err := formatters.TTY256.Format(&buf, style, iterator); err != nil {
+		return content
+	}
+	return buf.String()
+}
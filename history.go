@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// defaultHistoryCap bounds how many pipelines are kept in the in-memory ring.
+const defaultHistoryCap = 1000
+
+// history is a bounded ring of previously executed pipelines, persisted to
+// disk and browsable via Up/Down or a Ctrl+R reverse incremental search.
+type history struct {
+	entries []string
+	cap     int
+	path    string
+
+	cursor int    // index into entries while browsing with Up/Down; len(entries) means "not browsing"
+	draft  string // input stashed when browsing or searching began, restored on exit
+
+	searching bool
+	query     string
+	matchIdx  int // index into entries of the current reverse-search match (len(entries) before any match); the next search starts just below it
+
+	sessionStart int // len(entries) as of the last load/save; entries beyond this are new this session
+}
+
+// newHistory creates a history bounded to cap entries, backed by the file at
+// $XDG_STATE_HOME/txtflow/history, falling back to ~/.txtflow_history.
+func newHistory(cap int) *history {
+	return &history{cap: cap, path: historyFilePath()}
+}
+
+func historyFilePath() string {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "txtflow", "history")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".txtflow_history"
+	}
+	return filepath.Join(home, ".txtflow_history")
+}
+
+// load reads history entries from disk, oldest first. A missing file is not
+// an error: the user simply has no history yet.
+func (h *history) load() error {
+	f, err := os.Open(h.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			h.resetCursor()
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+
+	if err := flockShared(f); err == nil {
+		defer funlock(f)
+	}
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		h.append(scanner.Text())
+	}
+	h.resetCursor()
+	h.sessionStart = len(h.entries)
+	return scanner.Err()
+}
+
+// append records entry as the newest history item, de-duplicating against
+// the immediately previous entry and evicting the oldest once over cap.
+func (h *history) append(entry string) {
+	h.entries = appendDeduped(h.entries, entry)
+	if over := len(h.entries) - h.cap; over > 0 {
+		h.entries = h.entries[over:]
+		// Keep sessionStart pointing at the first entry added this session.
+		h.sessionStart = max(0, h.sessionStart-over)
+	}
+}
+
+// appendDeduped appends entry unless it's empty or equal to the previous
+// entry, the de-duplication rule shared by append and on-disk merging.
+func appendDeduped(entries []string, entry string) []string {
+	if entry == "" {
+		return entries
+	}
+	if len(entries) > 0 && entries[len(entries)-1] == entry {
+		return entries
+	}
+	return append(entries, entry)
+}
+
+// readHistoryEntries reads and de-dupes history lines from f, which must
+// already be open for reading; it seeks to the start first so it can be
+// reused on a handle that's also held for locking.
+func readHistoryEntries(f *os.File) ([]string, error) {
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	var entries []string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		entries = appendDeduped(entries, scanner.Text())
+	}
+	return entries, scanner.Err()
+}
+
+// save persists history atomically: lock the real path, re-read its current
+// on-disk contents and merge in whatever this session appended, then write
+// the merged result to a temp file and rename it over the real path. Locking
+// and re-reading the real file (rather than just the temp file) is what
+// keeps two concurrent txtflow instances from clobbering each other's
+// appends on exit.
+func (h *history) save() error {
+	if h.path == "" {
+		return nil
+	}
+	dir := filepath.Dir(h.path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	lockFile, err := os.OpenFile(h.path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	defer lockFile.Close()
+	if err := flockExclusive(lockFile); err == nil {
+		defer funlock(lockFile)
+	}
+
+	merged, err := readHistoryEntries(lockFile)
+	if err != nil {
+		return err
+	}
+	for _, entry := range h.entries[h.sessionStart:] {
+		merged = appendDeduped(merged, entry)
+	}
+	if len(merged) > h.cap {
+		merged = merged[len(merged)-h.cap:]
+	}
+
+	tmp, err := os.CreateTemp(dir, ".txtflow_history.*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // no-op once successfully renamed
+
+	w := bufio.NewWriter(tmp)
+	for _, entry := range merged {
+		fmt.Fprintln(w, entry)
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, h.path); err != nil {
+		return err
+	}
+
+	h.entries = merged
+	h.sessionStart = len(merged)
+	return nil
+}
+
+func (h *history) resetCursor() {
+	h.cursor = len(h.entries)
+}
+
+// up walks to an older entry, stashing draft the first time it's called.
+func (h *history) up(draft string) (string, bool) {
+	if len(h.entries) == 0 || h.cursor == 0 {
+		return "", false
+	}
+	if h.cursor == len(h.entries) {
+		h.draft = draft
+	}
+	h.cursor--
+	return h.entries[h.cursor], true
+}
+
+// down walks to a newer entry, restoring the stashed draft once past the
+// newest entry.
+func (h *history) down() (string, bool) {
+	if h.cursor >= len(h.entries) {
+		return "", false
+	}
+	h.cursor++
+	if h.cursor == len(h.entries) {
+		return h.draft, true
+	}
+	return h.entries[h.cursor], true
+}
+
+// startSearch begins a Ctrl+R reverse incremental search from the newest
+// entry, stashing draft so Esc can restore it.
+func (h *history) startSearch(draft string) {
+	h.searching = true
+	h.query = ""
+	h.draft = draft
+	h.matchIdx = len(h.entries)
+}
+
+func (h *history) stopSearch() {
+	h.searching = false
+	h.query = ""
+}
+
+// setQuery replaces the search query and returns the most recent match, if
+// any, scanning from the newest entry.
+func (h *history) setQuery(query string) (string, bool) {
+	h.query = query
+	h.matchIdx = len(h.entries)
+	if query == "" {
+		return "", false
+	}
+	return h.nextMatch()
+}
+
+// nextMatch steps to the next older entry containing query as a substring.
+func (h *history) nextMatch() (string, bool) {
+	if h.query == "" {
+		return "", false
+	}
+	for i := h.matchIdx - 1; i >= 0; i-- {
+		if strings.Contains(h.entries[i], h.query) {
+			h.matchIdx = i
+			return h.entries[i], true
+		}
+	}
+	return "", false
+}
+
+func (h *history) searchPrompt() string {
+	return fmt.Sprintf("(reverse-i-search)'%s': ", h.query)
+}
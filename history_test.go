@@ -0,0 +1,127 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestHistoryAppendDedupsAndEvicts(t *testing.T) {
+	h := newHistory(3)
+	h.append("a")
+	h.append("a") // consecutive duplicate, dropped
+	h.append("b")
+	h.append("c")
+	h.append("d") // over cap, evicts "a"
+
+	want := []string{"b", "c", "d"}
+	if len(h.entries) != len(want) {
+		t.Fatalf("entries = %v, want %v", h.entries, want)
+	}
+	for i, v := range want {
+		if h.entries[i] != v {
+			t.Fatalf("entries = %v, want %v", h.entries, want)
+		}
+	}
+}
+
+func TestHistoryUpDown(t *testing.T) {
+	h := newHistory(10)
+	h.append("one")
+	h.append("two")
+	h.resetCursor()
+
+	v, ok := h.up("draft")
+	if !ok || v != "two" {
+		t.Fatalf("up() = %q, %v, want two, true", v, ok)
+	}
+	v, ok = h.up("draft")
+	if !ok || v != "one" {
+		t.Fatalf("up() = %q, %v, want one, true", v, ok)
+	}
+	if _, ok := h.up("draft"); ok {
+		t.Fatalf("up() at oldest entry should return false")
+	}
+
+	v, ok = h.down()
+	if !ok || v != "two" {
+		t.Fatalf("down() = %q, %v, want two, true", v, ok)
+	}
+	v, ok = h.down()
+	if !ok || v != "draft" {
+		t.Fatalf("down() past newest should restore draft, got %q, %v", v, ok)
+	}
+}
+
+func TestHistorySearch(t *testing.T) {
+	h := newHistory(10)
+	h.append("grep foo")
+	h.append("sort | uniq")
+	h.append("grep bar")
+	h.resetCursor()
+
+	h.startSearch("draft")
+	v, ok := h.setQuery("grep")
+	if !ok || v != "grep bar" {
+		t.Fatalf("setQuery(grep) = %q, %v, want grep bar, true", v, ok)
+	}
+	v, ok = h.nextMatch()
+	if !ok || v != "grep foo" {
+		t.Fatalf("nextMatch() = %q, %v, want grep foo, true", v, ok)
+	}
+	if _, ok := h.nextMatch(); ok {
+		t.Fatalf("nextMatch() should exhaust matches")
+	}
+}
+
+func TestHistorySaveLoadRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "history")
+
+	h1 := &history{cap: 10, path: path}
+	h1.append("first")
+	h1.append("second")
+	if err := h1.save(); err != nil {
+		t.Fatalf("save() error = %v", err)
+	}
+
+	h2 := &history{cap: 10, path: path}
+	if err := h2.load(); err != nil {
+		t.Fatalf("load() error = %v", err)
+	}
+	if len(h2.entries) != 2 || h2.entries[0] != "first" || h2.entries[1] != "second" {
+		t.Fatalf("loaded entries = %v, want [first second]", h2.entries)
+	}
+}
+
+func TestHistorySaveMergesConcurrentAppends(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "history")
+
+	// Two "instances" start from the same (empty) on-disk state.
+	a := &history{cap: 10, path: path}
+	if err := a.load(); err != nil {
+		t.Fatalf("a.load() error = %v", err)
+	}
+	b := &history{cap: 10, path: path}
+	if err := b.load(); err != nil {
+		t.Fatalf("b.load() error = %v", err)
+	}
+
+	a.append("from-a")
+	if err := a.save(); err != nil {
+		t.Fatalf("a.save() error = %v", err)
+	}
+
+	b.append("from-b")
+	if err := b.save(); err != nil {
+		t.Fatalf("b.save() error = %v", err)
+	}
+
+	final := &history{cap: 10, path: path}
+	if err := final.load(); err != nil {
+		t.Fatalf("final.load() error = %v", err)
+	}
+	if len(final.entries) != 2 || final.entries[0] != "from-a" || final.entries[1] != "from-b" {
+		t.Fatalf("final entries = %v, want [from-a from-b] (b's save must not clobber a's)", final.entries)
+	}
+}
@@ -0,0 +1,11 @@
+//go:build windows
+
+package main
+
+import "os"
+
+// Windows has no flock equivalent wired up here; history writes are not
+// cross-process locked on this platform.
+func flockShared(f *os.File) error    { return nil }
+func flockExclusive(f *os.File) error { return nil }
+func funlock(f *os.File) error        { return nil }
@@ -3,11 +3,14 @@ package main
 import (
 	"bufio"
 	"bytes"
+	"context"
+	"flag"
 	"fmt"
 	"math"
 	"os"
 	"os/exec"
 	"strings"
+	"time"
 
 	"github.com/atotto/clipboard"
 	"github.com/charmbracelet/bubbles/key"
@@ -19,8 +22,8 @@ import (
 )
 
 const (
-	helpTextEditMode = "Tab - switch focus | Esc - clear | Ctrl+X - exit and print | Ctrl+C - exit"
-	helpTextViewMode = "Tab - switch focus | y - copy result | L - toggle line number | q - exit | Ctrl+X - exit and print | Ctrl+C - exit\n" +
+	helpTextEditMode = "Tab - complete/switch focus | Shift+Tab - prev completion | ↑↓ - history | Ctrl+R - search history | Esc - clear | Ctrl+X - exit and print | Ctrl+C - exit"
+	helpTextViewMode = "Tab - switch focus | y - copy result | L - toggle line number | H - cycle syntax highlight | s - save as script | + - grow stdin buffer | Ctrl+L - restart capture | q - exit | Ctrl+X - exit and print | Ctrl+C - exit\n" +
 		"hjkl/←↑↓→ - scroll | u/d - scroll half page | f/b/PgUp/PgDown - scroll full page | g/G - vertical 0/max | Home/End - horizontal 0/max"
 )
 
@@ -62,12 +65,28 @@ var (
 	helpStyle = lipgloss.NewStyle().
 			Foreground(helpColor).
 			Padding(0, horizontalMargin)
+
+	completionStyle = lipgloss.NewStyle().
+				Foreground(helpColor)
+	completionSelectedStyle = lipgloss.NewStyle().
+				Foreground(focusedBorderColor).
+				Bold(true)
+	completionRowStyle = lipgloss.NewStyle().
+				Padding(0, horizontalMargin)
 )
 
 // commandResultMsg is a message type sent when the command processing is done.
 type commandResultMsg struct {
-	output       string
-	errorMessage string // Human-readable error message, if any
+	output        string
+	errorMessage  string // Human-readable error message, if any
+	waitingForEOF bool   // Some stage is aggregating and stdin hasn't closed yet
+	canceled      bool   // A newer run superseded this one; the result should be dropped
+}
+
+// debounceMsg fires after a keystroke-triggered runCommand is debounced; gen
+// lets Update ignore it if a newer keystroke has since bumped inputGen.
+type debounceMsg struct {
+	gen int
 }
 
 type stdinMsg struct {
@@ -77,17 +96,39 @@ type stdinMsg struct {
 
 // model represents the state of our TUI application
 type model struct {
-	winWidth        int
-	winHeight       int
-	textInput       textinput.Model
-	viewport        viewport.Model
-	stdinContent    string // Content read from os.Stdin
-	rawOutput       string // Raw output after executing all commands
-	processedOutput string // Processed output, may contain line numbers
-	quitting        bool   // Flag to indicate if the app is quitting
-	command         string // Stores the command entered when exiting with Ctrl+X
-	errorMessage    string // Stores the error message to display in the UI
-	showLineNumber  bool   // Flag to indicate whether adding line numbers to output
+	winWidth       int
+	winHeight      int
+	textInput      textinput.Model
+	viewport       viewport.Model
+	stdinContent   string        // Content read from os.Stdin
+	stdinCh        chan stdinMsg // Retained so the UI can resume a paused reader
+	stdinCap       int           // Byte cap on stdinContent before capture pauses
+	stdinTruncated bool          // Sticky flag set once the cap is hit
+	stdinEOF       bool          // Whether readStdin has closed its channel
+	rawOutput      string        // Raw output after executing all commands
+	quitting       bool          // Flag to indicate if the app is quitting
+	command        string        // Stores the command entered when exiting with Ctrl+X
+	errorMessage   string        // Stores the error message to display in the UI
+	showLineNumber bool          // Flag to indicate whether adding line numbers to output
+
+	waitingForEOF bool               // The current pipeline is aggregating and stdin hasn't closed yet
+	cancelRun     context.CancelFunc // Cancels the in-flight pipeline, if any
+	inputGen      int                // Bumped on each keystroke; debounced runs check it's still current
+
+	hist *history // Persisted command history and Ctrl+R search state
+
+	completer        Completer // Produces tab-completion candidates
+	completions      []string  // Candidates for the token currently being completed
+	completionIdx    int       // Selected index within completions
+	completionActive bool      // Whether the completion popup is showing
+	completionHead   string    // Text before the token being completed
+	completionTail   string    // Text after the token being completed
+
+	highlightMode     highlightMode // off, auto-detected, or an explicit lexer
+	highlightLexerIdx int           // Index into highlightLexers when highlightMode is highlightExplicit
+
+	savingScript bool            // Whether the "save pipeline as" filename prompt is showing
+	saveInput    textinput.Model // Mini overlay textinput for the script filename
 
 	forceExit       key.Binding
 	exitAndPrint    key.Binding
@@ -95,12 +136,20 @@ type model struct {
 	enter           key.Binding
 	esc             key.Binding
 	tab             key.Binding
+	shiftTab        key.Binding
 	copyResult      key.Binding
 	toggleLineNum   key.Binding
+	toggleHighlight key.Binding
+	saveScript      key.Binding
+	growBuffer      key.Binding
+	resetBuffer     key.Binding
 	scrollTop       key.Binding
 	scrollBottom    key.Binding
 	scrollBeginning key.Binding
 	scrollEnd       key.Binding
+	historyUp       key.Binding
+	historyDown     key.Binding
+	historySearch   key.Binding
 }
 
 // initModel initializes the model with default values and reads stdin
@@ -114,10 +163,25 @@ func initModel() model {
 	vp := viewport.New(80, 20) // Initial width and height, will be adjusted
 	vp.SetHorizontalStep(10)   // Enable horizontal scroll in 10 incrementals
 
+	si := textinput.New()
+	si.Prompt = "Save pipeline as: "
+	si.CharLimit = 256
+
+	hist := newHistory(defaultHistoryCap)
+	if err := hist.load(); err != nil {
+		// A corrupt or unreadable history file shouldn't block startup;
+		// the user just starts with an empty history.
+		hist = newHistory(defaultHistoryCap)
+	}
+
 	m := model{
 		textInput:    ti,
 		viewport:     vp,
 		stdinContent: "",
+		stdinCap:     defaultStdinCapBytes,
+		hist:         hist,
+		completer:    newDefaultCompleter(),
+		saveInput:    si,
 
 		forceExit:       key.NewBinding(key.WithKeys("ctrl+c")),
 		exitAndPrint:    key.NewBinding(key.WithKeys("ctrl+x")),
@@ -125,12 +189,20 @@ func initModel() model {
 		enter:           key.NewBinding(key.WithKeys("enter")),
 		esc:             key.NewBinding(key.WithKeys("esc")),
 		tab:             key.NewBinding(key.WithKeys("tab")),
+		shiftTab:        key.NewBinding(key.WithKeys("shift+tab")),
 		copyResult:      key.NewBinding(key.WithKeys("y")),
 		toggleLineNum:   key.NewBinding(key.WithKeys("L")),
+		toggleHighlight: key.NewBinding(key.WithKeys("H")),
+		saveScript:      key.NewBinding(key.WithKeys("s")),
+		growBuffer:      key.NewBinding(key.WithKeys("+")),
+		resetBuffer:     key.NewBinding(key.WithKeys("ctrl+l")),
 		scrollTop:       key.NewBinding(key.WithKeys("g")),
 		scrollBottom:    key.NewBinding(key.WithKeys("G")),
 		scrollBeginning: key.NewBinding(key.WithKeys("home")),
 		scrollEnd:       key.NewBinding(key.WithKeys("end")),
+		historyUp:       key.NewBinding(key.WithKeys("up")),
+		historyDown:     key.NewBinding(key.WithKeys("down")),
+		historySearch:   key.NewBinding(key.WithKeys("ctrl+r")),
 	}
 	return m
 }
@@ -176,9 +248,28 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.handleWindowSizeMsg(msg)
 	case stdinMsg:
 		if msg.line != "" && msg.ch != nil {
-			m.stdinContent += msg.line + "\n"
-			m.errorMessage = ""
-			cmd = tea.Batch(streamStdin(msg.ch), m.runCommand())
+			m.stdinCh = msg.ch
+			switch {
+			case m.stdinTruncated:
+				// Paused until the user grows the buffer; don't re-arm
+				// streamStdin, so the reader goroutine blocks on its send.
+			case len(m.stdinContent)+len(msg.line)+1 > m.stdinCap:
+				m.stdinTruncated = true
+			default:
+				m.stdinContent += msg.line + "\n"
+				m.errorMessage = ""
+				cmd = tea.Batch(streamStdin(msg.ch), m.runCommand())
+			}
+		} else {
+			// The channel closed, or an empty stdin line arrived; either
+			// way streamStdin isn't re-armed, so no more stdin will be
+			// received and any pipeline deferred waiting for EOF can run.
+			m.stdinEOF = true
+			cmd = m.runCommand()
+		}
+	case debounceMsg:
+		if msg.gen == m.inputGen {
+			cmd = m.runCommand()
 		}
 	case commandResultMsg:
 		m.handleCommandResultMsg(msg)
@@ -193,27 +284,62 @@ func (m *model) handleKeyMsg(msg tea.KeyMsg) tea.Cmd {
 	case key.Matches(msg, m.forceExit):
 		m.quitting = true
 		return tea.Quit
+	case m.hist.searching:
+		return m.handleHistorySearchKeyMsg(msg)
+	case m.savingScript:
+		return m.handleSaveScriptKeyMsg(msg)
 	case key.Matches(msg, m.exitAndPrint):
 		m.command = m.textInput.Value()
 		m.quitting = true
 		return tea.Quit
 	case key.Matches(msg, m.enter):
+		m.commitInput()
 		m.textInput.Blur()
 	case key.Matches(msg, m.esc):
 		m.textInput.SetValue("")
 		m.textInput.Blur()
 	case key.Matches(msg, m.tab):
-		if m.textInput.Focused() {
+		if m.textInput.Focused() && cursorAtTokenEnd(m.textInput.Value(), m.textInput.Position()) {
+			m.triggerCompletion()
+		} else if m.textInput.Focused() {
 			m.textInput.Blur()
 		} else {
 			m.textInput.Focus()
 		}
+	case key.Matches(msg, m.shiftTab):
+		if m.completionActive {
+			m.cycleCompletion(-1)
+		}
 	default:
 		if m.textInput.Focused() {
-			// Every other key goes to the input box
-			m.textInput, _ = m.textInput.Update(msg)
-			m.errorMessage = ""
-			cmd = m.runCommand()
+			switch {
+			case key.Matches(msg, m.historySearch):
+				m.dismissCompletion()
+				m.hist.startSearch(m.textInput.Value())
+			case key.Matches(msg, m.historyUp):
+				if v, ok := m.hist.up(m.textInput.Value()); ok {
+					m.dismissCompletion()
+					m.textInput.SetValue(v)
+					m.textInput.CursorEnd()
+					m.errorMessage = ""
+					cmd = m.runCommand()
+				}
+			case key.Matches(msg, m.historyDown):
+				if v, ok := m.hist.down(); ok {
+					m.dismissCompletion()
+					m.textInput.SetValue(v)
+					m.textInput.CursorEnd()
+					m.errorMessage = ""
+					cmd = m.runCommand()
+				}
+			default:
+				// Every other key goes to the input box. Debounce so rapid
+				// typing doesn't spawn a storm of exec.Cmd invocations.
+				m.dismissCompletion()
+				m.textInput, _ = m.textInput.Update(msg)
+				m.errorMessage = ""
+				cmd = m.debounceRun()
+			}
 		} else {
 			// Handle keys on the view port
 			switch {
@@ -228,6 +354,29 @@ func (m *model) handleKeyMsg(msg tea.KeyMsg) tea.Cmd {
 			case key.Matches(msg, m.toggleLineNum):
 				m.showLineNumber = !m.showLineNumber
 				m.refreshOutput()
+			case key.Matches(msg, m.toggleHighlight):
+				m.cycleHighlight()
+			case key.Matches(msg, m.saveScript):
+				m.savingScript = true
+				m.saveInput.SetValue("")
+				m.saveInput.Focus()
+			case key.Matches(msg, m.growBuffer):
+				m.stdinCap *= 2
+				if m.stdinTruncated && m.stdinCh != nil {
+					m.stdinTruncated = false
+					cmd = streamStdin(m.stdinCh)
+				}
+			case key.Matches(msg, m.resetBuffer):
+				m.stdinContent = ""
+				m.updateOutput(m.stdinContent)
+				// A receiver is already pending unless streaming is paused
+				// (stdinTruncated): only re-arm in the paused case, or a
+				// second concurrent receiver would race it on the same
+				// unbuffered channel and leak once stdin eventually closes.
+				if m.stdinTruncated && m.stdinCh != nil {
+					m.stdinTruncated = false
+					cmd = streamStdin(m.stdinCh)
+				}
 			case key.Matches(msg, m.scrollTop):
 				m.viewport.GotoTop()
 			case key.Matches(msg, m.scrollBottom):
@@ -244,18 +393,182 @@ func (m *model) handleKeyMsg(msg tea.KeyMsg) tea.Cmd {
 	return cmd
 }
 
+// handleHistorySearchKeyMsg handles keystrokes while a Ctrl+R reverse
+// incremental search is active, previewing the current match in the input
+// and re-running the pipeline so the viewport reflects it live.
+func (m *model) handleHistorySearchKeyMsg(msg tea.KeyMsg) tea.Cmd {
+	switch {
+	case key.Matches(msg, m.historySearch):
+		if match, ok := m.hist.nextMatch(); ok {
+			m.textInput.SetValue(match)
+			m.textInput.CursorEnd()
+			return m.runCommand()
+		}
+		return nil
+	case key.Matches(msg, m.enter):
+		m.hist.stopSearch()
+		m.commitInput()
+		m.textInput.Blur()
+		return nil
+	case key.Matches(msg, m.esc):
+		m.textInput.SetValue(m.hist.draft)
+		m.textInput.CursorEnd()
+		m.hist.stopSearch()
+		return m.runCommand()
+	case msg.Type == tea.KeyBackspace:
+		if len(m.hist.query) == 0 {
+			return nil
+		}
+		query := m.hist.query[:len(m.hist.query)-1]
+		if match, ok := m.hist.setQuery(query); ok {
+			m.textInput.SetValue(match)
+		} else {
+			m.textInput.SetValue(m.hist.draft)
+		}
+		m.textInput.CursorEnd()
+		return m.runCommand()
+	case msg.Type == tea.KeyRunes:
+		query := m.hist.query + string(msg.Runes)
+		if match, ok := m.hist.setQuery(query); ok {
+			m.textInput.SetValue(match)
+			m.textInput.CursorEnd()
+			return m.runCommand()
+		}
+		return nil
+	default:
+		return nil
+	}
+}
+
+// commitInput runs the current pipeline synchronously to find out whether it
+// actually succeeds, rather than trusting m.errorMessage, which the typing
+// path clears optimistically before the debounced async run it describes
+// has even finished. Only a pipeline that truly ran clean (not blank, not
+// errored, not still waiting on EOF) gets appended to history.
+func (m *model) commitInput() {
+	if m.cancelRun != nil {
+		m.cancelRun()
+		m.cancelRun = nil
+	}
+	cmdStr := strings.TrimSpace(m.textInput.Value())
+	result := execPipeline(context.Background(), cmdStr, m.stdinContent, m.stdinEOF)
+	m.handleCommandResultMsg(result)
+	m.updateWindow()
+	if cmdStr != "" && result.errorMessage == "" && !result.waitingForEOF && !result.canceled {
+		m.hist.append(cmdStr)
+		m.hist.resetCursor()
+	}
+}
+
+// handleSaveScriptKeyMsg handles keystrokes while the "save pipeline as"
+// filename prompt is showing.
+func (m *model) handleSaveScriptKeyMsg(msg tea.KeyMsg) tea.Cmd {
+	switch {
+	case key.Matches(msg, m.enter):
+		path := strings.TrimSpace(m.saveInput.Value())
+		m.savingScript = false
+		m.saveInput.Blur()
+		if path == "" {
+			return nil
+		}
+		if err := writeScript(path, m.textInput.Value(), m.stdinContent, time.Now()); err != nil {
+			m.errorMessage = fmt.Sprintf("Failed to save script: %v", err)
+		} else {
+			m.errorMessage = ""
+		}
+		return nil
+	case key.Matches(msg, m.esc):
+		m.savingScript = false
+		m.saveInput.Blur()
+		return nil
+	default:
+		m.saveInput, _ = m.saveInput.Update(msg)
+		return nil
+	}
+}
+
+// triggerCompletion computes completion candidates for the token at the
+// cursor, applying the sole candidate immediately or opening the popup when
+// there's more than one. A repeated call while the popup is already open
+// cycles to the next candidate instead of recomputing.
+func (m *model) triggerCompletion() {
+	if m.completionActive {
+		m.cycleCompletion(1)
+		return
+	}
+	head, candidates, tail := m.completer.Complete(m.textInput.Value(), m.textInput.Position())
+	if len(candidates) == 0 {
+		return
+	}
+	m.completionHead = head
+	m.completionTail = tail
+	m.completions = candidates
+	m.completionIdx = 0
+	if len(candidates) > 1 {
+		m.completionActive = true
+	}
+	m.applyCompletion()
+}
+
+func (m *model) cycleCompletion(delta int) {
+	n := len(m.completions)
+	if n == 0 {
+		return
+	}
+	m.completionIdx = ((m.completionIdx+delta)%n + n) % n
+	m.applyCompletion()
+}
+
+func (m *model) applyCompletion() {
+	candidate := m.completions[m.completionIdx]
+	m.textInput.SetValue(m.completionHead + candidate + m.completionTail)
+	m.textInput.SetCursor(len(m.completionHead + candidate))
+}
+
+func (m *model) dismissCompletion() {
+	m.completionActive = false
+	m.completions = nil
+	m.completionIdx = 0
+}
+
+// renderCompletions renders up to 8 candidates in a single row, highlighting
+// the current selection, or "" when the popup isn't active.
+func (m model) renderCompletions() string {
+	if !m.completionActive || len(m.completions) == 0 {
+		return ""
+	}
+	const maxShown = 8
+	start := 0
+	if m.completionIdx >= maxShown {
+		start = m.completionIdx - maxShown + 1
+	}
+	end := min(start+maxShown, len(m.completions))
+
+	parts := make([]string, 0, end-start)
+	for i := start; i < end; i++ {
+		if i == m.completionIdx {
+			parts = append(parts, completionSelectedStyle.Render(m.completions[i]))
+		} else {
+			parts = append(parts, completionStyle.Render(m.completions[i]))
+		}
+	}
+	return completionRowStyle.Render(strings.Join(parts, "  "))
+}
+
 func (m *model) updateOutput(output string) {
 	m.rawOutput = strings.TrimSuffix(output, "\n")
-	m.processedOutput = addLineNumbers(m.rawOutput)
 	m.refreshOutput()
 }
 
+// refreshOutput re-renders the viewport content from m.rawOutput, applying
+// syntax highlighting before line numbers so the numbering always lines up
+// with actual output lines rather than any inserted ANSI sequences.
 func (m *model) refreshOutput() {
+	content := m.highlight(m.rawOutput)
 	if m.showLineNumber {
-		m.viewport.SetContent(m.processedOutput)
-	} else {
-		m.viewport.SetContent(m.rawOutput)
+		content = addLineNumbers(content)
 	}
+	m.viewport.SetContent(content)
 }
 
 func addLineNumbers(content string) string {
@@ -289,7 +602,7 @@ func (m *model) updateWindow() {
 	inputFocusedStyle = inputFocusedStyle.Width(availableWidth)
 	// Embed text in top border of the output panel
 	outputBorder := lipgloss.Border{
-		Top:         getBorderTopWithTitle(fmt.Sprintf(" Output (%d lines) ", countLines(m.rawOutput)), availableWidth-2),
+		Top:         getBorderTopWithTitle(m.outputBorderTitle(), availableWidth-2),
 		Bottom:      roundedBorder.Bottom,
 		Left:        roundedBorder.Left,
 		Right:       roundedBorder.Right,
@@ -316,6 +629,16 @@ func (m *model) updateWindow() {
 	helpTextRendered := helpStyle.Render(helpTextEditMode)
 	helpTextHeight := lipgloss.Height(helpTextRendered)
 
+	completionRowHeight := 0
+	if row := m.renderCompletions(); row != "" {
+		completionRowHeight = lipgloss.Height(row)
+	}
+
+	saveRowHeight := 0
+	if m.savingScript {
+		saveRowHeight = lipgloss.Height(inputStyle.Render(m.saveInput.View()))
+	}
+
 	// Gaps between elements
 	const (
 		gapAfterInput = 1
@@ -328,6 +651,8 @@ func (m *model) updateWindow() {
 	remainingHeight -= (inputBoxHeight + gapAfterInput)
 	remainingHeight -= outputStyle.GetVerticalFrameSize()
 	remainingHeight -= (gapBeforeHelp + helpTextHeight)
+	remainingHeight -= completionRowHeight
+	remainingHeight -= saveRowHeight
 	if errorBoxHeight > 0 {
 		remainingHeight -= (errorBoxHeight + gapAfterError)
 	}
@@ -354,31 +679,86 @@ func countLines(s string) int {
 	return count
 }
 
-// Build a custom border top for lipgloss that embeds a title in it
+// outputBorderTitle builds the output panel's border title from whichever
+// segments currently apply: the stdin buffer size (with a truncated marker)
+// when stdin is being piped in, a waiting-for-EOF hint while an aggregating
+// pipeline is deferred, the active highlight mode when it's not off, and
+// always the output line count.
+func (m *model) outputBorderTitle() string {
+	var segments []string
+	if m.stdinCh != nil {
+		truncatedSuffix := ""
+		if m.stdinTruncated {
+			truncatedSuffix = " (truncated)"
+		}
+		segments = append(segments, fmt.Sprintf("Input %s%s", formatBytes(m.stdinCap), truncatedSuffix))
+	}
+	if m.waitingForEOF {
+		segments = append(segments, "⏳ waiting for EOF")
+	}
+	if m.highlightMode != highlightOff {
+		segments = append(segments, fmt.Sprintf("Highlight: %s", m.highlightModeLabel()))
+	}
+	segments = append(segments, fmt.Sprintf("Output (%d lines)", countLines(m.rawOutput)))
+	return " " + strings.Join(segments, " | ") + " "
+}
+
+// getBorderTopWithTitle places title in a dashed border line width display
+// columns wide, after 4 leading dashes (1 when space is tight) and padded
+// with dashes on the right. Widths are computed with lipgloss.Width (not len/byte
+// length) so multi-byte and double-width runes like the waiting-for-EOF
+// hourglass don't throw off the padding or get cut mid-rune on truncation.
 func getBorderTopWithTitle(title string, width int) string {
 	const filler = "─"
 	const lead = 4
 
 	if width <= 0 {
 		return ""
-	} else if width <= len(title) {
-		return title[:width] // truncate if title too long
+	}
+
+	titleWidth := lipgloss.Width(title)
+	if width <= titleWidth {
+		return truncateToWidth(title, width)
 	}
 
 	// Compute how many dashes go on each side
 	var left, right int
-	if width <= len(title)+lead {
+	if width <= titleWidth+lead {
 		left = 1
 	} else {
 		left = lead
 	}
-	right = width - len(title) - left
+	right = width - titleWidth - left
 
 	return strings.Repeat(filler, left) + title + strings.Repeat(filler, right)
 }
 
+// truncateToWidth trims s to at most width display columns, stopping before
+// any rune that would overflow it instead of slicing by byte offset.
+func truncateToWidth(s string, width int) string {
+	var b strings.Builder
+	w := 0
+	for _, r := range s {
+		rw := lipgloss.Width(string(r))
+		if w+rw > width {
+			break
+		}
+		b.WriteRune(r)
+		w += rw
+	}
+	return b.String()
+}
+
 // Handles results from the user entered command
 func (m *model) handleCommandResultMsg(msg commandResultMsg) {
+	if msg.canceled {
+		// A newer run superseded this one; drop it on the floor.
+		return
+	}
+	m.waitingForEOF = msg.waitingForEOF
+	if msg.waitingForEOF {
+		return
+	}
 	if msg.errorMessage != "" {
 		m.errorMessage = msg.errorMessage
 		// Output remains unchanged (last good state or stdin content)
@@ -438,64 +818,93 @@ func parsePipedCommands(cmdStr string) ([]string, error) {
 	return commands, nil
 }
 
-// runCommand executes the user-entered command on the stdin content
-// in a separate goroutine and sends a commandResultMsg back.
-func (m *model) runCommand() tea.Cmd {
-	return func() tea.Msg {
-		trimmedCmdStr := strings.TrimSpace(m.textInput.Value())
-		if trimmedCmdStr == "" {
-			return commandResultMsg{output: m.stdinContent, errorMessage: ""}
+// debounceRun schedules a runCommand 150ms out, tagged with the current
+// inputGen so that if another keystroke arrives first, this stale tick is a
+// no-op instead of spawning its own exec.Cmd.
+func (m *model) debounceRun() tea.Cmd {
+	m.inputGen++
+	gen := m.inputGen
+	return tea.Tick(150*time.Millisecond, func(time.Time) tea.Msg {
+		return debounceMsg{gen: gen}
+	})
+}
+
+// execPipeline runs cmdStr against stdinSnapshot and reports the result.
+// Shared by the async path (runCommand, off in its own goroutine) and the
+// synchronous validation commitInput needs before deciding whether Enter
+// should append to history.
+func execPipeline(ctx context.Context, cmdStr, stdinSnapshot string, eofReached bool) commandResultMsg {
+	if cmdStr == "" {
+		return commandResultMsg{output: stdinSnapshot}
+	}
+
+	// Run commands one by one and pipe the previous command's output to next command's input
+	commands, err := parsePipedCommands(cmdStr)
+	if err != nil {
+		return commandResultMsg{errorMessage: err.Error()}
+	}
+
+	if !eofReached && isAggregating(commands) {
+		return commandResultMsg{waitingForEOF: true}
+	}
+
+	var lastOutput bytes.Buffer
+	lastOutput.WriteString(stdinSnapshot)
+
+	for _, cmdSegment := range commands {
+		cmdSegment = strings.TrimSpace(cmdSegment)
+		if cmdSegment == "" {
+			return commandResultMsg{errorMessage: "Syntax error: missing command between pipes"}
 		}
 
-		// Run commands one by one and pipe the previous command's output to next command's input
-		commands, err := parsePipedCommands(trimmedCmdStr)
+		parts, err := shlex.Split(cmdSegment)
+		if err != nil || len(parts) == 0 {
+			return commandResultMsg{errorMessage: fmt.Sprintf("Failed to parse command %s: %s", cmdSegment, err)}
+		}
+		cmd := exec.CommandContext(ctx, parts[0], parts[1:]...)
+		cmd.Stdin = &lastOutput
+
+		var output bytes.Buffer
+		var stderr bytes.Buffer
+		cmd.Stdout = &output
+		cmd.Stderr = &stderr
+
+		err = cmd.Run()
+		if ctx.Err() != nil {
+			return commandResultMsg{canceled: true}
+		}
 		if err != nil {
-			return commandResultMsg{
-				output:       "",
-				errorMessage: err.Error(),
+			errMsg := fmt.Sprintf("Error: Command '%s' failed. ", cmdSegment)
+			if stderr.Len() > 0 {
+				errMsg += strings.TrimSpace(stderr.String())
+			} else {
+				errMsg += err.Error() // Fallback to Go's error message if stderr is empty
 			}
+			return commandResultMsg{errorMessage: errMsg}
 		}
-		var lastOutput bytes.Buffer
-		lastOutput.WriteString(m.stdinContent)
-
-		for _, cmdSegment := range commands {
-			cmdSegment = strings.TrimSpace(cmdSegment)
-			if cmdSegment == "" {
-				return commandResultMsg{
-					output:       "",
-					errorMessage: "Syntax error: missing command between pipes",
-				}
-			}
 
-			parts, err := shlex.Split(cmdSegment)
-			if err != nil || len(parts) == 0 {
-				return commandResultMsg{
-					output:       "",
-					errorMessage: fmt.Sprintf("Failed to parse command %s: %s", cmdSegment, err),
-				}
-			}
-			cmd := exec.Command(parts[0], parts[1:]...)
-			cmd.Stdin = &lastOutput
-
-			var output bytes.Buffer
-			var stderr bytes.Buffer
-			cmd.Stdout = &output
-			cmd.Stderr = &stderr
-
-			err = cmd.Run()
-			if err != nil {
-				errMsg := fmt.Sprintf("Error: Command '%s' failed. ", cmdSegment)
-				if stderr.Len() > 0 {
-					errMsg += strings.TrimSpace(stderr.String())
-				} else {
-					errMsg += err.Error() // Fallback to Go's error message if stderr is empty
-				}
-				return commandResultMsg{output: "", errorMessage: errMsg}
-			}
+		lastOutput = output
+	}
+	return commandResultMsg{output: lastOutput.String()}
+}
 
-			lastOutput = output
-		}
-		return commandResultMsg{output: lastOutput.String(), errorMessage: ""}
+// runCommand executes the user-entered command on the stdin content in a
+// separate goroutine and sends a commandResultMsg back. Any pipeline still
+// in flight is canceled first, since a newer run always supersedes it.
+func (m *model) runCommand() tea.Cmd {
+	if m.cancelRun != nil {
+		m.cancelRun()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancelRun = cancel
+
+	trimmedCmdStr := strings.TrimSpace(m.textInput.Value())
+	stdinSnapshot := m.stdinContent
+	eofReached := m.stdinEOF
+
+	return func() tea.Msg {
+		defer cancel()
+		return execPipeline(ctx, trimmedCmdStr, stdinSnapshot, eofReached)
 	}
 }
 
@@ -504,11 +913,22 @@ func (m model) View() string {
 		return ""
 	}
 
+	inputView := m.textInput.View()
+	if m.hist.searching {
+		inputView = m.hist.searchPrompt() + m.textInput.Value()
+	}
+
 	var sections []string
 	if m.textInput.Focused() {
-		sections = append(sections, inputFocusedStyle.Render(m.textInput.View()))
+		sections = append(sections, inputFocusedStyle.Render(inputView))
 	} else {
-		sections = append(sections, inputStyle.Render(m.textInput.View()))
+		sections = append(sections, inputStyle.Render(inputView))
+	}
+	if m.savingScript {
+		sections = append(sections, inputStyle.Render(m.saveInput.View()))
+	}
+	if row := m.renderCompletions(); row != "" {
+		sections = append(sections, row)
 	}
 	if m.errorMessage != "" {
 		sections = append(sections, errorStyle.Render(m.errorMessage))
@@ -524,7 +944,25 @@ func (m model) View() string {
 }
 
 func main() {
+	loadPath := flag.String("load", "", "reopen a pipeline previously saved with 's'")
+	bufMiB := flag.Int("buf", defaultStdinCapBytes/(1024*1024), "stdin capture buffer cap, in MiB")
+	flag.Parse()
+
 	m := initModel()
+	m.stdinCap = *bufMiB * 1024 * 1024
+	if *loadPath != "" {
+		pipeline, stdinContent, hasInput, err := loadScript(*loadPath)
+		if err != nil {
+			fmt.Printf("Failed to load %s: %v\n", *loadPath, err)
+			os.Exit(1)
+		}
+		m.textInput.SetValue(pipeline)
+		m.textInput.CursorEnd()
+		if hasInput {
+			m.stdinContent = stdinContent
+		}
+	}
+
 	p := tea.NewProgram(m, tea.WithAltScreen())
 	finalModel, err := p.Run()
 	if err != nil {
@@ -534,6 +972,9 @@ func main() {
 
 	// Type assert the returned model back to our specific model type
 	if appModel, ok := finalModel.(model); ok {
+		if err := appModel.hist.save(); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to save command history: %v\n", err)
+		}
 		if appModel.command != "" {
 			fmt.Println(appModel.command)
 		}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const txtflowVersion = "0.1.0"
+
+// scriptStdinMarker is the heredoc delimiter used to embed captured stdin in
+// a saved script, unless content itself contains a line equal to it (see
+// pickHeredocMarker).
+const scriptStdinMarker = "TXTFLOW_STDIN"
+
+// pickHeredocMarker returns a heredoc delimiter guaranteed not to occur as a
+// standalone line in content, so arbitrary captured stdin (which may itself
+// contain a line reading "TXTFLOW_STDIN") can never terminate the heredoc
+// early. Starts from scriptStdinMarker and appends a numeric suffix until
+// there's no collision.
+func pickHeredocMarker(content string) string {
+	lines := strings.Split(content, "\n")
+	collides := func(marker string) bool {
+		for _, line := range lines {
+			if line == marker {
+				return true
+			}
+		}
+		return false
+	}
+	marker := scriptStdinMarker
+	for i := 0; collides(marker); i++ {
+		marker = fmt.Sprintf("%s_%d", scriptStdinMarker, i)
+	}
+	return marker
+}
+
+// writeScript renders pipeline as a standalone, executable bash script.
+// When stdinContent is non-empty it's embedded via a quoted heredoc bound to
+// the *first* pipeline stage (wrapped in a subshell so the redirect can't
+// bind to the last stage instead), with any remaining stages piped from it,
+// e.g. `(grep hello) <<'TXTFLOW_STDIN' | wc -l`, so the saved script reproduces the
+// whole pipeline rather than just feeding the heredoc to the final command.
+func writeScript(path, pipeline, stdinContent string, now time.Time) error {
+	var b strings.Builder
+	fmt.Fprintln(&b, "#!/usr/bin/env bash")
+	fmt.Fprintln(&b, "set -euo pipefail")
+	fmt.Fprintln(&b)
+	fmt.Fprintf(&b, "# Generated by txtflow %s on %s\n", txtflowVersion, now.Format(time.RFC3339))
+	fmt.Fprintf(&b, "# txtflow -load %s reopens this pipeline for editing.\n", filepath.Base(path))
+	fmt.Fprintln(&b)
+
+	if stdinContent != "" {
+		commands, err := parsePipedCommands(pipeline)
+		if err != nil || len(commands) == 0 {
+			commands = []string{pipeline}
+		}
+		marker := pickHeredocMarker(stdinContent)
+		line := fmt.Sprintf("(%s) <<'%s'", commands[0], marker)
+		if rest := commands[1:]; len(rest) > 0 {
+			line += " | " + strings.Join(rest, " | ")
+		}
+		fmt.Fprintln(&b, line)
+		b.WriteString(stdinContent)
+		if !strings.HasSuffix(stdinContent, "\n") {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "%s\n", marker)
+	} else {
+		fmt.Fprintln(&b, pipeline)
+	}
+
+	return os.WriteFile(path, []byte(b.String()), 0o755)
+}
+
+// loadScript recovers the pipeline (and any embedded stdin heredoc) from a
+// script previously written by writeScript.
+func loadScript(path string) (pipeline, stdinContent string, hasInput bool, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", "", false, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+
+	var inHeredoc bool
+	var marker string
+	var stdinLines []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case inHeredoc:
+			if line == marker {
+				inHeredoc = false
+				continue
+			}
+			stdinLines = append(stdinLines, line)
+		case pipeline != "":
+			continue // only expect heredoc body (handled above) after the pipeline line
+		case strings.HasPrefix(line, "#"), line == "", line == "set -euo pipefail":
+			continue
+		default:
+			if idx := strings.Index(line, " <<'"); idx >= 0 {
+				// line looks like `(first) <<'MARKER'` optionally followed
+				// by ` | rest-of-pipeline`.
+				afterQuote := line[idx+4:]
+				end := strings.Index(afterQuote, "'")
+				if end < 0 {
+					pipeline = line
+					break
+				}
+				marker = afterQuote[:end]
+				first := strings.TrimSpace(line[:idx])
+				first = strings.TrimPrefix(first, "(")
+				first = strings.TrimSuffix(first, ")")
+				pipeline = first
+				if rest := strings.TrimSpace(afterQuote[end+1:]); rest != "" {
+					rest = strings.TrimPrefix(rest, "|")
+					pipeline += " | " + strings.TrimSpace(rest)
+				}
+				hasInput = true
+				inHeredoc = true
+			} else {
+				pipeline = line
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", "", false, err
+	}
+	if pipeline == "" {
+		return "", "", false, fmt.Errorf("no pipeline found in %s", path)
+	}
+	if hasInput {
+		stdinContent = strings.Join(stdinLines, "\n")
+		if len(stdinLines) > 0 {
+			stdinContent += "\n"
+		}
+	}
+	return pipeline, stdinContent, hasInput, nil
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteLoadScriptRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "pipeline.sh")
+
+	pipeline := "grep hello | wc -l"
+	stdin := "hello world\nhello again\ngoodbye\n"
+
+	if err := writeScript(path, pipeline, stdin, time.Now()); err != nil {
+		t.Fatalf("writeScript() error = %v", err)
+	}
+
+	gotPipeline, gotStdin, hasInput, err := loadScript(path)
+	if err != nil {
+		t.Fatalf("loadScript() error = %v", err)
+	}
+	if !hasInput {
+		t.Fatalf("hasInput = false, want true")
+	}
+	if gotPipeline != pipeline {
+		t.Fatalf("pipeline = %q, want %q", gotPipeline, pipeline)
+	}
+	if gotStdin != stdin {
+		t.Fatalf("stdin = %q, want %q", gotStdin, stdin)
+	}
+}
+
+func TestWriteScriptMultiStageHeredocBindsFirstStage(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "pipeline.sh")
+
+	if err := writeScript(path, "grep hello | wc -l", "hello\nbye\n", time.Now()); err != nil {
+		t.Fatalf("writeScript() error = %v", err)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("os.ReadFile() error = %v", err)
+	}
+	data := string(raw)
+	// The heredoc redirect must bind to the first stage (wrapped in a
+	// subshell), not the last, or the saved script won't reproduce the
+	// pipeline's filtering.
+	if !strings.Contains(data, "(grep hello) <<'") {
+		t.Fatalf("script does not bind heredoc to first stage:\n%s", data)
+	}
+	if !strings.Contains(data, "| wc -l") {
+		t.Fatalf("script lost the rest of the pipeline:\n%s", data)
+	}
+}
+
+func TestWriteScriptAvoidsMarkerCollision(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "pipeline.sh")
+
+	// stdinContent contains a line identical to the default marker; a naive
+	// fixed delimiter would let the heredoc terminate early here.
+	stdin := "foo\n" + scriptStdinMarker + "\nbar\n"
+	if err := writeScript(path, "cat", stdin, time.Now()); err != nil {
+		t.Fatalf("writeScript() error = %v", err)
+	}
+
+	gotPipeline, gotStdin, hasInput, err := loadScript(path)
+	if err != nil {
+		t.Fatalf("loadScript() error = %v", err)
+	}
+	if !hasInput || gotPipeline != "cat" {
+		t.Fatalf("pipeline = %q, hasInput = %v", gotPipeline, hasInput)
+	}
+	if gotStdin != stdin {
+		t.Fatalf("stdin = %q, want %q (heredoc marker collided with content)", gotStdin, stdin)
+	}
+}
+
+func TestPickHeredocMarkerAvoidsCollision(t *testing.T) {
+	content := scriptStdinMarker + "\nsome data\n"
+	marker := pickHeredocMarker(content)
+	if marker == scriptStdinMarker {
+		t.Fatalf("pickHeredocMarker returned a marker that collides with content: %q", marker)
+	}
+	for _, line := range strings.Split(content, "\n") {
+		if line == marker {
+			t.Fatalf("picked marker %q still collides with a content line", marker)
+		}
+	}
+}
+
+func TestWriteScriptNoStdin(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "pipeline.sh")
+
+	if err := writeScript(path, "sort", "", time.Now()); err != nil {
+		t.Fatalf("writeScript() error = %v", err)
+	}
+	pipeline, stdin, hasInput, err := loadScript(path)
+	if err != nil {
+		t.Fatalf("loadScript() error = %v", err)
+	}
+	if hasInput || stdin != "" {
+		t.Fatalf("hasInput = %v, stdin = %q, want false, \"\"", hasInput, stdin)
+	}
+	if pipeline != "sort" {
+		t.Fatalf("pipeline = %q, want sort", pipeline)
+	}
+}